@@ -0,0 +1,202 @@
+package db
+
+import (
+	"container/list"
+	"fmt"
+	"hash/fnv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Cache is the pluggable interface behind DB's query cache. New installs a
+// default LRUCache unless a different implementation is supplied via
+// WithCache.
+type Cache interface {
+	Get(key CacheKey) (any, bool)
+	Set(key CacheKey, value any)
+	DeleteTable(table string)
+	Stats() CacheStats
+}
+
+// CacheStats reports cumulative cache activity, useful for tuning size/TTL.
+type CacheStats struct {
+	Hits      uint64
+	Misses    uint64
+	Evictions uint64
+}
+
+const lruShardCount = 16
+
+// LRUCache is a sharded, size- and TTL-bounded Cache implementation. Its
+// entry cap and optional byte cap are split evenly across its 16 shards
+// (each holds at most maxEntries/16+1 entries), evicting a shard's
+// least-recently-used entries first; the total can therefore exceed
+// maxEntries by at most 16, and a full shard evicts even while others have
+// room.
+type LRUCache struct {
+	ttl          time.Duration
+	maxEntries   int
+	maxBytes     int
+	shards       [lruShardCount]*lruShard
+	hits, misses uint64
+	evictions    uint64
+}
+
+type lruEntry struct {
+	key       CacheKey
+	value     any
+	size      int
+	expiresAt time.Time
+}
+
+type lruShard struct {
+	mu    sync.Mutex
+	order *list.List
+	index map[CacheKey]*list.Element
+	bytes int
+}
+
+// LRUOption configures an LRUCache constructed via NewLRUCache.
+type LRUOption func(*LRUCache)
+
+// WithMaxBytes caps the cache's total estimated size in bytes, in addition
+// to maxEntries. The size of a stored value is a rough estimate, not an
+// exact measurement.
+func WithMaxBytes(n int) LRUOption {
+	return func(c *LRUCache) { c.maxBytes = n }
+}
+
+// NewLRUCache creates an LRUCache holding about maxEntries items, each
+// expiring ttl after it was stored. maxEntries <= 0 means unbounded by
+// count (only ttl and, if set via WithMaxBytes, total size apply).
+func NewLRUCache(maxEntries int, ttl time.Duration, opts ...LRUOption) *LRUCache {
+	c := &LRUCache{ttl: ttl, maxEntries: maxEntries}
+	for i := range c.shards {
+		c.shards[i] = &lruShard{
+			order: list.New(),
+			index: map[CacheKey]*list.Element{},
+		}
+	}
+	for _, o := range opts {
+		o(c)
+	}
+	return c
+}
+
+func (c *LRUCache) shardFor(key CacheKey) *lruShard {
+	h := fnv.New32a()
+	h.Write([]byte(key.TableName))
+	h.Write([]byte{0})
+	h.Write([]byte(key.Query))
+	h.Write([]byte{0})
+	h.Write([]byte(key.Type))
+	return c.shards[h.Sum32()%lruShardCount]
+}
+
+func (c *LRUCache) Get(key CacheKey) (any, bool) {
+	s := c.shardFor(key)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	el, ok := s.index[key]
+	if !ok {
+		atomic.AddUint64(&c.misses, 1)
+		return nil, false
+	}
+
+	e := el.Value.(*lruEntry)
+	if !e.expiresAt.IsZero() && time.Now().After(e.expiresAt) {
+		s.remove(el)
+		atomic.AddUint64(&c.misses, 1)
+		atomic.AddUint64(&c.evictions, 1)
+		return nil, false
+	}
+
+	s.order.MoveToFront(el)
+	atomic.AddUint64(&c.hits, 1)
+	return e.value, true
+}
+
+func (c *LRUCache) Set(key CacheKey, value any) {
+	s := c.shardFor(key)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var expiresAt time.Time
+	if c.ttl > 0 {
+		expiresAt = time.Now().Add(c.ttl)
+	}
+	size := c.estimateSize(value)
+
+	if el, ok := s.index[key]; ok {
+		e := el.Value.(*lruEntry)
+		s.bytes += size - e.size
+		e.value, e.size, e.expiresAt = value, size, expiresAt
+		s.order.MoveToFront(el)
+	} else {
+		el := s.order.PushFront(&lruEntry{key: key, value: value, size: size, expiresAt: expiresAt})
+		s.index[key] = el
+		s.bytes += size
+	}
+
+	perShardMax := 0
+	if c.maxEntries > 0 {
+		perShardMax = c.maxEntries/lruShardCount + 1
+	}
+	perShardBytes := 0
+	if c.maxBytes > 0 {
+		perShardBytes = c.maxBytes/lruShardCount + 1
+	}
+
+	for (perShardMax > 0 && s.order.Len() > perShardMax) || (perShardBytes > 0 && s.bytes > perShardBytes) {
+		back := s.order.Back()
+		if back == nil {
+			break
+		}
+		s.remove(back)
+		atomic.AddUint64(&c.evictions, 1)
+	}
+}
+
+func (c *LRUCache) DeleteTable(table string) {
+	for _, s := range c.shards {
+		s.mu.Lock()
+		for k, el := range s.index {
+			if k.TableName == table {
+				s.remove(el)
+			}
+		}
+		s.mu.Unlock()
+	}
+}
+
+func (c *LRUCache) Stats() CacheStats {
+	return CacheStats{
+		Hits:      atomic.LoadUint64(&c.hits),
+		Misses:    atomic.LoadUint64(&c.misses),
+		Evictions: atomic.LoadUint64(&c.evictions),
+	}
+}
+
+// remove must be called with s.mu held.
+func (s *lruShard) remove(el *list.Element) {
+	e := el.Value.(*lruEntry)
+	s.order.Remove(el)
+	delete(s.index, e.key)
+	s.bytes -= e.size
+}
+
+// estimateSize gives a rough byte size for an arbitrary cached value, used
+// to enforce WithMaxBytes. It's skipped entirely when no byte cap is
+// configured, since stringifying the whole value on every Set would be a
+// real hot-path cost for callers who only want the entry-count bound. Even
+// when it does run it's intentionally cheap rather than exact: Go's reflect
+// package can't measure the size of arbitrary interior slices/maps without
+// a full walk.
+func (c *LRUCache) estimateSize(v any) int {
+	if c.maxBytes <= 0 {
+		return 0
+	}
+	return len(fmt.Sprintf("%v", v))
+}
@@ -0,0 +1,141 @@
+package db
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// schedule computes the next time a scheduled job should run, given the
+// last time it fired (or was registered).
+type schedule interface {
+	next(from time.Time) time.Time
+}
+
+// everySchedule implements the "@every <duration>" form.
+type everySchedule struct {
+	interval time.Duration
+}
+
+func (s everySchedule) next(from time.Time) time.Time {
+	return from.Add(s.interval)
+}
+
+// fieldMatcher reports whether a cron field (minute, hour, ...) matches v.
+type fieldMatcher func(v int) bool
+
+// cronSchedule implements standard 5-field "minute hour dom month dow" cron.
+type cronSchedule struct {
+	minute, hour, dom, month, dow fieldMatcher
+}
+
+func (s cronSchedule) next(from time.Time) time.Time {
+	// Cron has minute resolution; start searching at the next whole minute.
+	t := from.Truncate(time.Minute).Add(time.Minute)
+
+	// Four years covers every month/day/hour/minute combination, including
+	// Feb 29; this bounds the loop instead of spinning forever on an
+	// unsatisfiable schedule. Day-of-month and day-of-week must both match,
+	// so a Feb 29 spec that also restricts the weekday can go longer than
+	// that without a match, and next then returns from.
+	for limit := t.AddDate(4, 0, 0); t.Before(limit); t = t.Add(time.Minute) {
+		if s.month(int(t.Month())) && s.dom(t.Day()) && s.dow(int(t.Weekday())) &&
+			s.hour(t.Hour()) && s.minute(t.Minute()) {
+			return t
+		}
+	}
+	return from
+}
+
+// parseSchedule parses either "@every <duration>" or a standard 5-field
+// cron expression ("minute hour day-of-month month day-of-week").
+func parseSchedule(spec string) (schedule, error) {
+	spec = strings.TrimSpace(spec)
+
+	if rest, ok := strings.CutPrefix(spec, "@every "); ok {
+		d, err := time.ParseDuration(strings.TrimSpace(rest))
+		if err != nil {
+			return nil, fmt.Errorf("db: parsing @every interval %q: %w", rest, err)
+		}
+		return everySchedule{interval: d}, nil
+	}
+
+	fields := strings.Fields(spec)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("db: cron spec %q must have 5 fields (minute hour dom month dow)", spec)
+	}
+
+	minute, err := parseField(fields[0], 0, 59)
+	if err != nil {
+		return nil, fmt.Errorf("db: minute field: %w", err)
+	}
+	hour, err := parseField(fields[1], 0, 23)
+	if err != nil {
+		return nil, fmt.Errorf("db: hour field: %w", err)
+	}
+	dom, err := parseField(fields[2], 1, 31)
+	if err != nil {
+		return nil, fmt.Errorf("db: day-of-month field: %w", err)
+	}
+	month, err := parseField(fields[3], 1, 12)
+	if err != nil {
+		return nil, fmt.Errorf("db: month field: %w", err)
+	}
+	dow, err := parseField(fields[4], 0, 6)
+	if err != nil {
+		return nil, fmt.Errorf("db: day-of-week field: %w", err)
+	}
+
+	return cronSchedule{minute: minute, hour: hour, dom: dom, month: month, dow: dow}, nil
+}
+
+// parseField parses one cron field: "*", "*/step", "a-b", "a-b/step", or a
+// comma-separated list of any of those.
+func parseField(f string, min, max int) (fieldMatcher, error) {
+	allowed := map[int]bool{}
+
+	for _, part := range strings.Split(f, ",") {
+		rangePart, step := part, 1
+		if before, after, ok := strings.Cut(part, "/"); ok {
+			rangePart = before
+			s, err := strconv.Atoi(after)
+			if err != nil || s <= 0 {
+				return nil, fmt.Errorf("invalid step in %q", part)
+			}
+			step = s
+		}
+
+		lo, hi := min, max
+		switch {
+		case rangePart == "*":
+			// lo/hi already cover the field's full range.
+		case strings.Contains(rangePart, "-"):
+			before, after, _ := strings.Cut(rangePart, "-")
+			l, err := strconv.Atoi(before)
+			if err != nil {
+				return nil, fmt.Errorf("invalid range in %q", part)
+			}
+			h, err := strconv.Atoi(after)
+			if err != nil {
+				return nil, fmt.Errorf("invalid range in %q", part)
+			}
+			lo, hi = l, h
+		default:
+			v, err := strconv.Atoi(rangePart)
+			if err != nil {
+				return nil, fmt.Errorf("invalid value %q", part)
+			}
+			lo, hi = v, v
+		}
+
+		if lo < min || hi > max || lo > hi {
+			return nil, fmt.Errorf("value %q out of range [%d,%d]", part, min, max)
+		}
+		for v := lo; v <= hi; v += step {
+			allowed[v] = true
+		}
+	}
+
+	return func(v int) bool { return allowed[v] }, nil
+}
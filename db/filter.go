@@ -0,0 +1,219 @@
+package db
+
+import (
+	"fmt"
+	"hash/fnv"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+// UseFilter builds a WHERE/GROUP BY/ORDER BY/START/LIMIT clause from the
+// non-nil fields of f (a pointer to a struct) and appends it to q. It
+// returns the full query string plus a bindings map of $name -> value, so
+// callers pass values to surrealdb's Query as bound parameters instead of
+// interpolating them into SQL.
+//
+// Each field's name comes from its `surql:"name"` tag, falling back to a
+// plain `json:"name"` tag so existing filter structs keep working unchanged,
+// and then to the lowercased Go field name.
+// Append `,op=...` to pick a comparison other than equality: eq (default),
+// neq, gt, gte, lt, lte, like, in, contains, between, null, notnull. A field
+// whose type is itself a filter struct (a pointer to a struct that isn't
+// time.Time) is treated as a nested group and OR'd together instead of
+// AND'd with the rest.
+//
+// Five field names are reserved as query modifiers rather than WHERE
+// clauses: Group (GROUP BY), Limit (LIMIT), OrderBy/OrderDesc
+// (ORDER BY ... ASC|DESC) and Start (START, i.e. offset).
+func UseFilter(f interface{}, q string) (string, map[string]any) {
+	if f == nil || reflect.ValueOf(f).IsNil() {
+		return q, nil
+	}
+
+	vars := map[string]any{}
+	counter := 0
+	if where := buildWhere(f, vars, &counter); where != "" {
+		q += " WHERE " + where
+	}
+
+	v := reflect.ValueOf(f).Elem()
+	if fl := v.FieldByName("Group"); fl.IsValid() && isSetPtr(fl) {
+		q += fmt.Sprintf(" GROUP BY %s", fl.Elem().Interface())
+	}
+	if fl := v.FieldByName("OrderBy"); fl.IsValid() && isSetPtr(fl) {
+		dir := "ASC"
+		if df := v.FieldByName("OrderDesc"); df.IsValid() && isSetPtr(df) {
+			if desc, ok := df.Elem().Interface().(bool); ok && desc {
+				dir = "DESC"
+			}
+		}
+		q += fmt.Sprintf(" ORDER BY %s %s", fl.Elem().Interface(), dir)
+	}
+	if fl := v.FieldByName("Start"); fl.IsValid() && isSetPtr(fl) {
+		q += fmt.Sprintf(" START %d", fl.Elem().Interface())
+	}
+	if fl := v.FieldByName("Limit"); fl.IsValid() && isSetPtr(fl) {
+		q += fmt.Sprintf(" LIMIT %d", fl.Elem().Interface())
+	}
+
+	return q, vars
+}
+
+// controlFields are consumed directly by UseFilter as query modifiers and
+// never contribute a WHERE clause.
+var controlFields = map[string]bool{
+	"Group": true, "Limit": true, "OrderBy": true, "OrderDesc": true, "Start": true,
+}
+
+func isSetPtr(fl reflect.Value) bool {
+	return fl.Kind() == reflect.Ptr && !fl.IsNil()
+}
+
+// buildWhere walks the exported, non-nil pointer fields of f and joins
+// their clauses with AND. f must be a pointer to a struct (or a nested
+// struct reached while descending into an OR group).
+func buildWhere(f interface{}, vars map[string]any, counter *int) string {
+	return strings.Join(buildClauses(f, vars, counter), " AND ")
+}
+
+// buildClauses walks the exported, non-nil pointer fields of f and returns
+// their rendered clauses unjoined, so callers can combine them with AND
+// (buildWhere) or OR (a nested group, below) structurally instead of
+// rewriting the joined SQL string — doing the latter would also rewrite
+// the internal AND of a sibling between clause.
+func buildClauses(f interface{}, vars map[string]any, counter *int) []string {
+	rv := reflect.ValueOf(f)
+	if rv.Kind() == reflect.Ptr {
+		rv = rv.Elem()
+	}
+	rt := rv.Type()
+
+	var clauses []string
+	for i := 0; i < rv.NumField(); i++ {
+		name := rt.Field(i).Name
+		if controlFields[name] {
+			continue
+		}
+
+		fl := rv.Field(i)
+		if fl.Kind() != reflect.Ptr || fl.IsNil() {
+			continue
+		}
+		elem := fl.Elem()
+
+		if elem.Kind() == reflect.Struct && !isTime(elem) {
+			if sub := buildClauses(fl.Interface(), vars, counter); len(sub) > 0 {
+				clauses = append(clauses, "("+strings.Join(sub, " OR ")+")")
+			}
+			continue
+		}
+
+		field, op := fieldTag(rt.Field(i), name)
+		*counter++
+		varBase := fmt.Sprintf("%s%d", sanitizeVarName(field), *counter)
+		if clause := buildClause(field, op, varBase, elem.Interface(), vars); clause != "" {
+			clauses = append(clauses, clause)
+		}
+	}
+	return clauses
+}
+
+func isTime(v reflect.Value) bool {
+	_, ok := v.Interface().(time.Time)
+	return ok
+}
+
+// fieldTag resolves the SurrealQL field name and comparison operator for a
+// struct field, preferring a `surql` tag and falling back to `json` for
+// backward compatibility with filter structs written before operators
+// existed.
+func fieldTag(sf reflect.StructField, fallback string) (field, op string) {
+	tag := sf.Tag.Get("surql")
+	if tag == "" {
+		tag = sf.Tag.Get("json")
+	}
+
+	parts := strings.Split(tag, ",")
+	field = parts[0]
+	if field == "" || field == "-" {
+		field = strings.ToLower(fallback)
+	}
+
+	op = "eq"
+	for _, p := range parts[1:] {
+		if rest, ok := strings.CutPrefix(p, "op="); ok {
+			op = rest
+		}
+	}
+	return field, op
+}
+
+var opSymbols = map[string]string{
+	"eq": "=", "neq": "!=", "gt": ">", "gte": ">=", "lt": "<", "lte": "<=",
+	"like": "~", "in": "IN", "contains": "CONTAINS",
+}
+
+// buildClause renders a single comparison and records its bound value(s) in
+// vars, keyed by $-prefixed names derived from varBase.
+func buildClause(field, op, varBase string, val any, vars map[string]any) string {
+	switch op {
+	case "null":
+		return fmt.Sprintf("%s = NONE", field)
+	case "notnull":
+		return fmt.Sprintf("%s != NONE", field)
+	case "between":
+		rv := reflect.ValueOf(val)
+		if (rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array) || rv.Len() != 2 {
+			return ""
+		}
+		from, to := varBase+"_from", varBase+"_to"
+		vars[from] = rv.Index(0).Interface()
+		vars[to] = rv.Index(1).Interface()
+		return fmt.Sprintf("($%s <= %s AND %s <= $%s)", from, field, field, to)
+	default:
+		sym, ok := opSymbols[op]
+		if !ok {
+			sym = "="
+		}
+		vars[varBase] = val
+		return fmt.Sprintf("%s %s $%s", field, sym, varBase)
+	}
+}
+
+// sanitizeVarName keeps generated $name bindings valid SurrealQL
+// identifiers even when the filter field name isn't one (e.g. has dots
+// from a nested path).
+func sanitizeVarName(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') || r == '_' {
+			b.WriteRune(r)
+		} else {
+			b.WriteRune('_')
+		}
+	}
+	return b.String()
+}
+
+// hashBindings returns a stable hash of a bindings map so two SelectQuery
+// values that share the same parameterized SQL but different filter values
+// don't collide in the cache.
+func hashBindings(vars map[string]any) string {
+	if len(vars) == 0 {
+		return ""
+	}
+
+	keys := make([]string, 0, len(vars))
+	for k := range vars {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	h := fnv.New64a()
+	for _, k := range keys {
+		fmt.Fprintf(h, "%s=%v;", k, vars[k])
+	}
+	return fmt.Sprintf("%x", h.Sum64())
+}
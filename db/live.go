@@ -0,0 +1,126 @@
+package db
+
+import (
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/surrealdb/surrealdb.go"
+)
+
+// defaultLivePollInterval is the poll interval liveInvalidator falls back
+// to when the DB wasn't built with WithLivePollInterval.
+const defaultLivePollInterval = 2 * time.Second
+
+// liveInvalidator keeps cached query results fresh for writes that happen
+// outside this process, by polling each table that has cached queries on an
+// interval and comparing a cheap fingerprint (row count) against the last
+// poll, only clearing the table's cache entries when that fingerprint
+// actually changed.
+//
+// The pinned surrealdb.go client (v0.2.1) lets us open a SurrealDB LIVE
+// SELECT but does not expose the resulting push notifications on its public
+// API, so there's nothing to gain from opening one here — this deliberately
+// doesn't call db.s.Live, to avoid leaking a server-side live query this
+// code can neither consume nor kill. That means invalidation is
+// count-based: it catches inserts and deletes but misses an in-place field
+// update on a record that doesn't also change the row count. Upgrading the
+// client later should replace this whole poll loop with its notification
+// channel instead.
+type liveInvalidator struct {
+	db       *DB
+	interval time.Duration
+
+	mu     sync.Mutex
+	tables map[string]func()
+}
+
+func newLiveInvalidator(d *DB, interval time.Duration) *liveInvalidator {
+	if interval <= 0 {
+		interval = defaultLivePollInterval
+	}
+	return &liveInvalidator{
+		db:       d,
+		interval: interval,
+		tables:   map[string]func(){},
+	}
+}
+
+// watch ensures table has an active invalidation poll loop, starting one if
+// this is the first time it's been seen.
+func (li *liveInvalidator) watch(table string) {
+	li.mu.Lock()
+	defer li.mu.Unlock()
+	if _, ok := li.tables[table]; ok {
+		return
+	}
+
+	stop := make(chan struct{})
+	li.tables[table] = func() { close(stop) }
+
+	go li.pollLoop(table, stop)
+}
+
+func (li *liveInvalidator) pollLoop(table string, stop chan struct{}) {
+	ticker := time.NewTicker(li.interval)
+	defer ticker.Stop()
+
+	// Prime last with the fingerprint as of watch() so the first tick
+	// doesn't clear a table that hasn't changed since it started being
+	// watched.
+	last, haveLast := "", false
+	if fp, err := li.fingerprint(table); err == nil {
+		last, haveLast = fp, true
+	}
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			fp, err := li.fingerprint(table)
+			if err != nil {
+				log.Printf("db: live poll on %s failed: %v", table, err)
+				continue
+			}
+			if haveLast && fp == last {
+				continue
+			}
+			last, haveLast = fp, true
+			li.db.clearCache(table)
+		}
+	}
+}
+
+// fingerprint returns table's current row count as a string, used to
+// detect whether anything changed since the last poll. It's a coarse
+// stand-in for a real change notification (see liveInvalidator's doc
+// comment): cheap enough to run every poll, but blind to updates that
+// don't add or remove rows.
+func (li *liveInvalidator) fingerprint(table string) (string, error) {
+	r, err := li.db.s.Query(fmt.Sprintf("SELECT count() FROM %s GROUP ALL", table), nil)
+	if err != nil {
+		return "", err
+	}
+
+	var rows []struct {
+		Count int `json:"count"`
+	}
+	if _, err := surrealdb.UnmarshalRaw(r, &rows); err != nil {
+		return "", err
+	}
+	if len(rows) == 0 {
+		return "0", nil
+	}
+	return fmt.Sprintf("%d", rows[0].Count), nil
+}
+
+func (li *liveInvalidator) close() {
+	li.mu.Lock()
+	defer li.mu.Unlock()
+	for _, stop := range li.tables {
+		stop()
+	}
+	li.tables = map[string]func(){}
+}
@@ -2,18 +2,32 @@ package db
 
 import (
 	"fmt"
-	"reflect"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/surrealdb/surrealdb.go"
 )
 
+// CacheKey identifies a cached query result. Type distinguishes different
+// generic instantiations of the same query (e.g. GeneralSelect[A] vs
+// GeneralSelect[B] against the same table/filter) so they don't collide,
+// and Bindings is a stable hash of the query's $-parameters so different
+// filter values against the same parameterized SQL don't collide either.
 type CacheKey struct {
 	TableName string
 	Query     string
+	Bindings  string
+	Type      string
 }
 
+// defaultCacheEntries and defaultCacheTTL size the LRUCache New installs
+// when the caller doesn't supply one via WithCache.
+const (
+	defaultCacheEntries = 1024
+	defaultCacheTTL     = 5 * time.Minute
+)
+
 // SelectQuery represents a structure for generating SQL SELECT queries.
 // TableName is the name of the table in the database.
 // Fields is an optional slice of fields (columns) to be selected. If empty, all fields (*) are selected.
@@ -24,9 +38,10 @@ type SelectQuery struct {
 	Filter    any
 }
 
-// String method generates a SQL SELECT query string based on the SelectQuery values.
-// It uses the UseFilter function to add any filter conditions to the query.
-func (s SelectQuery) String() string {
+// Build generates the SQL SELECT query for this SelectQuery along with its
+// $name-bound filter parameters, ready to pass as-is to surrealdb's Query.
+// Values are never interpolated into the returned SQL.
+func (s SelectQuery) Build() (string, map[string]any) {
 	var q string
 	if len(s.Fields) == 0 {
 		q = fmt.Sprintf("SELECT * FROM %s", s.TableName)
@@ -36,6 +51,15 @@ func (s SelectQuery) String() string {
 	return UseFilter(s.Filter, q)
 }
 
+// String returns the parameterized SQL for this SelectQuery, without its
+// bound values. Kept for callers that only want to log/inspect the shape of
+// the query; executing it should go through Build so filter values are
+// bound rather than interpolated.
+func (s SelectQuery) String() string {
+	q, _ := s.Build()
+	return q
+}
+
 // NewSelectAll is a function that generates a new SelectQuery for selecting all fields from a specific table.
 // It accepts the table name as an argument.
 func NewSelectAll(t string) SelectQuery {
@@ -63,17 +87,50 @@ func (s SelectQuery) WithFilter(f any) SelectQuery {
 	return s
 }
 
-// DB represents a wrapper over surrealdb.DB that includes a concurrent map for caching purposes.
+// DB represents a wrapper over surrealdb.DB that includes a pluggable query cache.
 type DB struct {
-	s *surrealdb.DB
-	c sync.Map
+	s                *surrealdb.DB
+	cache            Cache
+	live             *liveInvalidator
+	liveEnabled      bool
+	livePollInterval time.Duration
+	scheduler        *Scheduler
+
+	retentionMu sync.RWMutex
+	retention   []retentionRule
+}
+
+// Option configures a DB constructed via New.
+type Option func(*DB)
+
+// WithCache installs a custom Cache implementation in place of the default
+// LRUCache.
+func WithCache(c Cache) Option {
+	return func(d *DB) { d.cache = c }
+}
+
+// WithLiveInvalidation polls the row count of each table that accumulates
+// cached queries (see liveInvalidator) and clears that table's cache entries
+// when the count changes, so inserts and deletes made by other processes
+// invalidate this DB's cache. No SurrealDB LIVE SELECT is opened, and an
+// in-place update that leaves the row count unchanged is not detected.
+func WithLiveInvalidation(enabled bool) Option {
+	return func(d *DB) { d.liveEnabled = enabled }
+}
+
+// WithLivePollInterval overrides how often a live-invalidated table is
+// polled for changes (see liveInvalidator). It has no effect unless
+// WithLiveInvalidation(true) is also given. Defaults to
+// defaultLivePollInterval.
+func WithLivePollInterval(interval time.Duration) Option {
+	return func(d *DB) { d.livePollInterval = interval }
 }
 
 // New is a function that creates a new instance of DB.
 // It establishes a connection to the SurrealDB with the provided URL and credentials,
 // then switches to the specified namespace and database.
 // If successful, it returns a pointer to the DB instance; otherwise, it returns an error.
-func New(url, user, pass, ns, db string) (*DB, error) {
+func New(url, user, pass, ns, db string, opts ...Option) (*DB, error) {
 	s, err := surrealdb.New(url)
 	if err != nil {
 		return nil, err
@@ -90,27 +147,53 @@ func New(url, user, pass, ns, db string) (*DB, error) {
 		return nil, err
 	}
 
-	return &DB{
-		s: s,
-		c: sync.Map{},
-	}, nil
+	d := &DB{
+		s:         s,
+		cache:     NewLRUCache(defaultCacheEntries, defaultCacheTTL),
+		scheduler: newScheduler(),
+	}
+	for _, o := range opts {
+		o(d)
+	}
+	if d.liveEnabled {
+		d.live = newLiveInvalidator(d, d.livePollInterval)
+	}
+
+	return d, nil
 }
 
 func (d *DB) Close() {
+	d.scheduler.close()
+	if d.live != nil {
+		d.live.close()
+	}
 	d.s.Close()
 }
 
-func (d *DB) putQueryToCache(s SelectQuery, value any) {
-	d.c.Store(CacheKey{
+// Stats returns cumulative query cache activity, useful for tuning cache
+// size and TTL.
+func (d *DB) Stats() CacheStats {
+	return d.cache.Stats()
+}
+
+func (d *DB) putQueryToCache(s SelectQuery, sql string, vars map[string]any, typ string, value any) {
+	if d.liveEnabled {
+		d.live.watch(s.TableName)
+	}
+	d.cache.Set(CacheKey{
 		TableName: s.TableName,
-		Query:     s.String(),
+		Query:     sql,
+		Bindings:  hashBindings(vars),
+		Type:      typ,
 	}, value)
 }
 
-func (d *DB) getQueryFromCache(s SelectQuery) (any, error) {
-	if v, ok := d.c.Load(CacheKey{
+func (d *DB) getQueryFromCache(s SelectQuery, sql string, vars map[string]any, typ string) (any, error) {
+	if v, ok := d.cache.Get(CacheKey{
 		TableName: s.TableName,
-		Query:     s.String(),
+		Query:     sql,
+		Bindings:  hashBindings(vars),
+		Type:      typ,
 	}); ok {
 		return v, nil
 	}
@@ -119,12 +202,7 @@ func (d *DB) getQueryFromCache(s SelectQuery) (any, error) {
 }
 
 func (d *DB) clearCache(t string) {
-	d.c.Range(func(k, v interface{}) bool {
-		if k.(CacheKey).TableName == t {
-			d.c.Delete(k)
-		}
-		return true
-	})
+	d.cache.DeleteTable(t)
 }
 
 func (d *DB) GetSurrealDB() *surrealdb.DB {
@@ -161,15 +239,18 @@ func GeneralCreate[T any](d *DB, thing string, data map[string]interface{}) (*T,
 // s: SelectQuery structure which encapsulates the SELECT query details
 // Returns a slice of records of type T or an error.
 func GeneralSelect[T any](d *DB, s SelectQuery) ([]T, error) {
-	cv, err := d.getQueryFromCache(s)
+	var zero T
+	typ := fmt.Sprintf("%T", zero)
+	sql, vars := s.Build()
+
+	cv, err := d.getQueryFromCache(s, sql, vars, typ)
 	if err == nil {
-		p, ok := cv.([]T)
-		if ok {
+		if p, ok := cv.([]T); ok {
 			return p, nil
 		}
 	}
 
-	r, err := d.s.Query(s.String(), nil)
+	r, err := d.s.Query(sql, vars)
 	if err != nil {
 		return nil, err
 	}
@@ -182,7 +263,7 @@ func GeneralSelect[T any](d *DB, s SelectQuery) ([]T, error) {
 	if !ok {
 		return []T{}, nil
 	}
-	d.putQueryToCache(s, p)
+	d.putQueryToCache(s, sql, vars, typ, p)
 	return p, nil
 }
 
@@ -198,15 +279,17 @@ func GeneralSelect[T any](d *DB, s SelectQuery) ([]T, error) {
 //
 // Returns a slice of map[string]any records or an error.
 func GeneralSelectAny(d *DB, s SelectQuery) ([]map[string]any, error) {
-	cv, err := d.getQueryFromCache(s)
+	const typ = "any"
+	sql, vars := s.Build()
+
+	cv, err := d.getQueryFromCache(s, sql, vars, typ)
 	if err == nil {
-		p, ok := cv.([]map[string]any)
-		if ok {
+		if p, ok := cv.([]map[string]any); ok {
 			return p, nil
 		}
 	}
 
-	r, err := d.s.Query(s.String(), nil)
+	r, err := d.s.Query(sql, vars)
 	if err != nil {
 		return nil, err
 	}
@@ -219,7 +302,7 @@ func GeneralSelectAny(d *DB, s SelectQuery) ([]map[string]any, error) {
 	if !ok {
 		return []map[string]any{}, nil
 	}
-	d.putQueryToCache(s, p)
+	d.putQueryToCache(s, sql, vars, typ, p)
 	return p, nil
 }
 
@@ -276,56 +359,3 @@ func GeneralDelete[T any](d *DB, id string) (*T, error) {
 	d.clearCache(m[0])
 	return &p, nil
 }
-
-// UseFilter takes an interface and a query string as input and adds WHERE and LIMIT clauses to the query
-// based on the non-nil fields of the interface. It ignores the "limit" field while constructing WHERE clauses.
-// f: Filter interface with optional fields
-// q: Query string to which filters will be appended
-// Returns the modified query string.
-func UseFilter(f interface{}, q string) string {
-	if reflect.ValueOf(f).IsNil() {
-		return q
-	}
-
-	v := reflect.ValueOf(f).Elem()
-	typeOfT := v.Type()
-
-	var w []string
-
-	for i := 0; i < v.NumField(); i++ {
-		fl := v.Field(i)
-		if fl.Kind() == reflect.Ptr && !fl.IsNil() {
-			flv := reflect.Indirect(fl).Interface()
-			tag := typeOfT.Field(i).Tag.Get("json")
-			tagParts := strings.Split(tag, ",")
-			fln := tagParts[0]
-
-			if fln == "limit" || fln == "group" {
-				continue
-			}
-
-			switch v := flv.(type) {
-			case string:
-				w = append(w, fmt.Sprintf("%s = '%s'", fln, v))
-			case bool:
-				w = append(w, fmt.Sprintf("%s = %t", fln, v))
-			case int:
-				w = append(w, fmt.Sprintf("%s = %d", fln, v))
-			}
-		}
-	}
-
-	if len(w) > 0 {
-		q += " WHERE " + strings.Join(w, " AND ")
-	}
-
-	if fln := v.FieldByName("Group"); fln.IsValid() && !fln.IsNil() {
-		q += fmt.Sprintf(" GROUP BY %s", fln.Elem().Interface())
-	}
-
-	if fll := v.FieldByName("Limit"); fll.IsValid() && !fll.IsNil() {
-		q += fmt.Sprintf(" LIMIT %d", fll.Elem().Interface())
-	}
-
-	return q
-}
@@ -0,0 +1,319 @@
+// Package migrate runs versioned SurrealQL schema migrations against a
+// *db.DB. Migrations are read as NNNN_name.up.surql / NNNN_name.down.surql
+// pairs from an fs.FS (an embedded filesystem or a plain directory via
+// os.DirFS), and the set of applied versions is tracked in a table inside
+// the target SurrealDB namespace/database itself.
+package migrate
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/surrealdb/surrealdb.go"
+
+	"github.com/AltairInglorious/nexus/db"
+)
+
+// ErrNilVersion is returned by Version when no migration has ever been applied.
+var ErrNilVersion = errors.New("migrate: no migration has been applied")
+
+// ErrLocked is returned when another Migrator already holds the advisory lock.
+var ErrLocked = errors.New("migrate: database is locked by another migrator")
+
+// ErrDirty is returned by Up/Down/Goto when the tracked version is dirty and
+// needs a Force call before anything else can run.
+type ErrDirty struct {
+	Version int
+}
+
+func (e ErrDirty) Error() string {
+	return fmt.Sprintf("migrate: database is dirty at version %d, fix manually and call Force", e.Version)
+}
+
+var filenameRE = regexp.MustCompile(`^(\d+)_(.+)\.(up|down)\.surql$`)
+
+// migration holds the up/down SurrealQL bodies for a single numbered schema change.
+type migration struct {
+	version int
+	name    string
+	up      string
+	down    string
+}
+
+// versionRow is the persisted shape of the current migration state.
+type versionRow struct {
+	ID        string    `json:"id,omitempty"`
+	Version   int       `json:"version"`
+	Dirty     bool      `json:"dirty"`
+	AppliedAt time.Time `json:"applied_at"`
+}
+
+// Migrator runs versioned schema migrations against a *db.DB.
+type Migrator struct {
+	db         *db.DB
+	migrations []migration
+	table      string
+	lockTable  string
+}
+
+// New creates a Migrator that reads migration pairs from fsys and tracks
+// applied versions in table (defaults to "schema_migrations" when empty).
+func New(d *db.DB, fsys fs.FS, table string) (*Migrator, error) {
+	if table == "" {
+		table = "schema_migrations"
+	}
+
+	migrations, err := loadMigrations(fsys)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Migrator{
+		db:         d,
+		migrations: migrations,
+		table:      table,
+		lockTable:  table + "_lock",
+	}, nil
+}
+
+// loadMigrations walks fsys for NNNN_name.up.surql / NNNN_name.down.surql
+// pairs and returns them sorted by version.
+func loadMigrations(fsys fs.FS) ([]migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, fmt.Errorf("migrate: reading migrations dir: %w", err)
+	}
+
+	byVersion := map[int]*migration{}
+	for _, e := range entries {
+		if e.IsDir() {
+			continue
+		}
+		m := filenameRE.FindStringSubmatch(e.Name())
+		if m == nil {
+			continue
+		}
+		version, err := strconv.Atoi(m[1])
+		if err != nil {
+			return nil, fmt.Errorf("migrate: bad version in %q: %w", e.Name(), err)
+		}
+		body, err := fs.ReadFile(fsys, e.Name())
+		if err != nil {
+			return nil, fmt.Errorf("migrate: reading %q: %w", e.Name(), err)
+		}
+
+		mig, ok := byVersion[version]
+		if !ok {
+			mig = &migration{version: version, name: m[2]}
+			byVersion[version] = mig
+		}
+		if m[3] == "up" {
+			mig.up = string(body)
+		} else {
+			mig.down = string(body)
+		}
+	}
+
+	out := make([]migration, 0, len(byVersion))
+	for _, mig := range byVersion {
+		out = append(out, *mig)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].version < out[j].version })
+	return out, nil
+}
+
+// lock takes the advisory lock by creating a well-known record; SurrealDB
+// rejects CREATE on an existing id, so a failed create means someone else
+// already holds it.
+func (m *Migrator) lock() error {
+	if _, err := m.db.GetSurrealDB().Create(m.lockTable+":lock", map[string]interface{}{
+		"locked_at": time.Now(),
+	}); err != nil {
+		return ErrLocked
+	}
+	return nil
+}
+
+func (m *Migrator) unlock() error {
+	_, err := m.db.GetSurrealDB().Delete(m.lockTable + ":lock")
+	return err
+}
+
+// currentVersion returns the tracked version and dirty flag, or ErrNilVersion
+// if the table is empty (no migration has ever run).
+func (m *Migrator) currentVersion() (int, bool, error) {
+	r, err := m.db.GetSurrealDB().Query(fmt.Sprintf("SELECT * FROM %s LIMIT 1", m.table), nil)
+	if err != nil {
+		return 0, false, err
+	}
+
+	var rows []versionRow
+	ok, err := surrealdb.UnmarshalRaw(r, &rows)
+	if err != nil {
+		return 0, false, err
+	}
+	if !ok || len(rows) == 0 {
+		return 0, false, ErrNilVersion
+	}
+	return rows[0].Version, rows[0].Dirty, nil
+}
+
+// setVersion replaces the tracked state with a single row; the table only
+// ever holds the most recent version, matching how it's queried.
+func (m *Migrator) setVersion(version int, dirty bool) error {
+	if _, err := m.db.GetSurrealDB().Query(fmt.Sprintf("DELETE %s", m.table), nil); err != nil {
+		return err
+	}
+	_, err := m.db.GetSurrealDB().Create(m.table, map[string]interface{}{
+		"version":    version,
+		"dirty":      dirty,
+		"applied_at": time.Now(),
+	})
+	return err
+}
+
+// run executes a single migration step's SurrealQL body, marking the tracked
+// version dirty first so a crash mid-step is visible on the next run.
+func (m *Migrator) run(version int, body string) error {
+	if err := m.setVersion(version, true); err != nil {
+		return err
+	}
+	if strings.TrimSpace(body) != "" {
+		if _, err := m.db.GetSurrealDB().Query(body, nil); err != nil {
+			return err
+		}
+	}
+	return m.setVersion(version, false)
+}
+
+// Up applies all migrations newer than the current version, in order.
+func (m *Migrator) Up() error {
+	return m.withLock(func() error {
+		cur, dirty, err := m.currentVersion()
+		if err != nil {
+			if !errors.Is(err, ErrNilVersion) {
+				return err
+			}
+			cur = 0
+		}
+		if dirty {
+			return ErrDirty{Version: cur}
+		}
+
+		for _, mig := range m.migrations {
+			if mig.version <= cur {
+				continue
+			}
+			if err := m.run(mig.version, mig.up); err != nil {
+				return fmt.Errorf("migrate: up to %d: %w", mig.version, err)
+			}
+		}
+		return nil
+	})
+}
+
+// Down rolls back every applied migration, in reverse order.
+func (m *Migrator) Down() error {
+	return m.withLock(func() error {
+		cur, dirty, err := m.currentVersion()
+		if err != nil {
+			if errors.Is(err, ErrNilVersion) {
+				return nil
+			}
+			return err
+		}
+		if dirty {
+			return ErrDirty{Version: cur}
+		}
+
+		for i := len(m.migrations) - 1; i >= 0; i-- {
+			mig := m.migrations[i]
+			if mig.version > cur {
+				continue
+			}
+			prev := 0
+			if i > 0 {
+				prev = m.migrations[i-1].version
+			}
+			if err := m.run(prev, mig.down); err != nil {
+				return fmt.Errorf("migrate: down from %d: %w", mig.version, err)
+			}
+			cur = prev
+		}
+		return nil
+	})
+}
+
+// Goto applies the up migrations with versions in (current, target], or the
+// down migrations with versions in (target, current]. The tracked version
+// ends equal to target when target is 0 or a migration version; otherwise it
+// ends at the nearest migration version below target.
+func (m *Migrator) Goto(target int) error {
+	return m.withLock(func() error {
+		cur, dirty, err := m.currentVersion()
+		if err != nil {
+			if !errors.Is(err, ErrNilVersion) {
+				return err
+			}
+			cur = 0
+		}
+		if dirty {
+			return ErrDirty{Version: cur}
+		}
+
+		if target > cur {
+			for _, mig := range m.migrations {
+				if mig.version <= cur || mig.version > target {
+					continue
+				}
+				if err := m.run(mig.version, mig.up); err != nil {
+					return fmt.Errorf("migrate: up to %d: %w", mig.version, err)
+				}
+			}
+			return nil
+		}
+
+		for i := len(m.migrations) - 1; i >= 0; i-- {
+			mig := m.migrations[i]
+			if mig.version > cur || mig.version <= target {
+				continue
+			}
+			prev := 0
+			if i > 0 {
+				prev = m.migrations[i-1].version
+			}
+			if err := m.run(prev, mig.down); err != nil {
+				return fmt.Errorf("migrate: down from %d: %w", mig.version, err)
+			}
+		}
+		return nil
+	})
+}
+
+// Force sets the tracked version without running any migration body. Use it
+// to clear a dirty state after manually fixing up a failed step.
+func (m *Migrator) Force(version int) error {
+	return m.withLock(func() error {
+		return m.setVersion(version, false)
+	})
+}
+
+// Version returns the currently tracked version and whether it is dirty.
+// It returns ErrNilVersion if no migration has ever been applied.
+func (m *Migrator) Version() (int, bool, error) {
+	return m.currentVersion()
+}
+
+func (m *Migrator) withLock(fn func() error) error {
+	if err := m.lock(); err != nil {
+		return err
+	}
+	defer m.unlock()
+	return fn()
+}
@@ -0,0 +1,53 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// retentionRule prunes rows from table older than duration, compared
+// against field.
+type retentionRule struct {
+	table    string
+	field    string
+	duration time.Duration
+}
+
+// RegisterRetention adds a rule deleting rows from table where field is
+// older than duration. Rules take effect once a retention job is started
+// with StartRetentionJob; registering one without starting the job is a
+// no-op.
+func (d *DB) RegisterRetention(table, field string, duration time.Duration) {
+	d.retentionMu.Lock()
+	defer d.retentionMu.Unlock()
+	d.retention = append(d.retention, retentionRule{table: table, field: field, duration: duration})
+}
+
+// StartRetentionJob runs every rule registered via RegisterRetention on
+// spec (see Schedule), deleting rows older than their configured duration.
+// It returns a cancel func that stops the sweep.
+func (d *DB) StartRetentionJob(spec string) func() {
+	return d.Schedule(spec, func(ctx context.Context) error {
+		d.retentionMu.RLock()
+		rules := append([]retentionRule(nil), d.retention...)
+		d.retentionMu.RUnlock()
+
+		var firstErr error
+		for _, r := range rules {
+			// $dur is bound as a string and cast to a duration in the
+			// query itself, so the rule's table/field names are the only
+			// thing interpolated directly (developer-controlled, not
+			// request data).
+			q := fmt.Sprintf("DELETE FROM %s WHERE %s < time::now() - <duration>$dur", r.table, r.field)
+			if _, err := d.s.Query(q, map[string]any{"dur": r.duration.String()}); err != nil {
+				if firstErr == nil {
+					firstErr = fmt.Errorf("retention on %s: %w", r.table, err)
+				}
+				continue
+			}
+			d.clearCache(r.table)
+		}
+		return firstErr
+	})
+}
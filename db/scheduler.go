@@ -0,0 +1,162 @@
+package db
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+)
+
+// JobStats reports a scheduled job's last and next run, for tuning
+// schedules and spotting stuck jobs.
+type JobStats struct {
+	NextRun time.Time
+	LastRun time.Time
+	LastErr error
+}
+
+// scheduledJob runs fn on its schedule, never overlapping two runs of
+// itself: if fn is still running when the schedule fires again, that firing
+// is skipped and the next one is computed from the current time instead.
+type scheduledJob struct {
+	sched schedule
+	fn    func(context.Context) error
+
+	mu      sync.Mutex
+	running bool
+	nextRun time.Time
+	lastRun time.Time
+	lastErr error
+
+	stop chan struct{}
+}
+
+func (j *scheduledJob) stats() JobStats {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return JobStats{NextRun: j.nextRun, LastRun: j.lastRun, LastErr: j.lastErr}
+}
+
+func (j *scheduledJob) loop() {
+	for {
+		j.mu.Lock()
+		wait := time.Until(j.nextRun)
+		j.mu.Unlock()
+		if wait < 0 {
+			wait = 0
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-j.stop:
+			timer.Stop()
+			return
+		case now := <-timer.C:
+			j.fire(now)
+		}
+	}
+}
+
+func (j *scheduledJob) fire(now time.Time) {
+	j.mu.Lock()
+	if j.running {
+		j.nextRun = j.sched.next(now)
+		j.mu.Unlock()
+		return
+	}
+	j.running = true
+	j.mu.Unlock()
+
+	err := j.fn(context.Background())
+	if err != nil {
+		log.Printf("db: scheduled job failed: %v", err)
+	}
+
+	j.mu.Lock()
+	j.running = false
+	j.lastRun = time.Now()
+	j.lastErr = err
+	j.nextRun = j.sched.next(j.lastRun)
+	j.mu.Unlock()
+}
+
+// Scheduler runs recurring jobs on cron-style or "@every" schedules.
+type Scheduler struct {
+	mu   sync.Mutex
+	jobs []*scheduledJob
+}
+
+func newScheduler() *Scheduler {
+	return &Scheduler{}
+}
+
+// add parses spec and starts a goroutine driving fn on that schedule. The
+// returned cancel func stops that goroutine; it's safe to call more than
+// once.
+func (s *Scheduler) add(spec string, fn func(context.Context) error) (func(), error) {
+	sched, err := parseSchedule(spec)
+	if err != nil {
+		return nil, err
+	}
+
+	j := &scheduledJob{sched: sched, fn: fn, nextRun: sched.next(time.Now()), stop: make(chan struct{})}
+
+	s.mu.Lock()
+	s.jobs = append(s.jobs, j)
+	s.mu.Unlock()
+
+	go j.loop()
+
+	var once sync.Once
+	return func() { once.Do(func() { close(j.stop) }) }, nil
+}
+
+// stats returns a stats snapshot for every job still registered with the
+// scheduler (cancelled jobs are not removed from the list, so their last
+// known stats remain inspectable).
+func (s *Scheduler) stats() []JobStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	out := make([]JobStats, len(s.jobs))
+	for i, j := range s.jobs {
+		out[i] = j.stats()
+	}
+	return out
+}
+
+func (s *Scheduler) close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, j := range s.jobs {
+		select {
+		case <-j.stop:
+		default:
+			close(j.stop)
+		}
+	}
+}
+
+// Schedule registers job to run on spec, a cron-style "minute hour dom
+// month dow" expression or "@every <duration>" (e.g. "@every 30s"). Runs of
+// the same job never overlap: if job is still running when its schedule
+// fires again, that firing is skipped.
+//
+// If spec fails to parse, the error is only logged and Schedule returns a
+// no-op cancel func; the package exports no separate way to validate a
+// spec.
+func (d *DB) Schedule(spec string, job func(context.Context) error) (cancel func()) {
+	cancel, err := d.scheduler.add(spec, job)
+	if err != nil {
+		log.Printf("db: schedule %q: %v", spec, err)
+		return func() {}
+	}
+	return cancel
+}
+
+// ScheduledJobs returns stats for every job registered via Schedule (and
+// the built-in warmers/retention jobs, since they're implemented on top of
+// it), for tuning schedules or spotting stuck jobs.
+func (d *DB) ScheduledJobs() []JobStats {
+	return d.scheduler.stats()
+}
@@ -0,0 +1,50 @@
+package db
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/surrealdb/surrealdb.go"
+)
+
+// WarmCache re-runs each of queries on spec (see Schedule), always forcing
+// a fresh query against the database and overwriting the cached result via
+// Set — it deliberately bypasses GeneralSelect's cache-first read path,
+// since reading through the cache would just return the still-live entry
+// untouched and leave its expiresAt exactly where it was, defeating the
+// warm. It returns a cancel func that stops the warmer.
+func WarmCache[T any](d *DB, spec string, queries ...SelectQuery) func() {
+	return d.Schedule(spec, func(ctx context.Context) error {
+		var firstErr error
+		for _, q := range queries {
+			if err := refreshQuery[T](d, q); err != nil && firstErr == nil {
+				firstErr = err
+			}
+		}
+		return firstErr
+	})
+}
+
+// refreshQuery runs q against the database unconditionally and stores the
+// result in the cache, overwriting whatever was there before.
+func refreshQuery[T any](d *DB, s SelectQuery) error {
+	var zero T
+	typ := fmt.Sprintf("%T", zero)
+	sql, vars := s.Build()
+
+	r, err := d.s.Query(sql, vars)
+	if err != nil {
+		return err
+	}
+
+	var p []T
+	ok, err := surrealdb.UnmarshalRaw(r, &p)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		p = []T{}
+	}
+	d.putQueryToCache(s, sql, vars, typ, p)
+	return nil
+}
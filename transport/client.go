@@ -0,0 +1,108 @@
+package transport
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/nats-io/nats.go"
+)
+
+// encodeRequest marshals req with t's default codec and stamps
+// Content-Type/Accept headers on the outgoing message, so Handle decodes the
+// body with the same codec and replies with it too.
+func (t *Transport) encodeRequest(req any) (*nats.Msg, error) {
+	b, err := t.codec.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+	return &nats.Msg{
+		Data: b,
+		Header: nats.Header{
+			ContentTypeHeader: []string{t.codec.ContentType()},
+			AcceptHeader:      []string{t.codec.ContentType()},
+		},
+	}, nil
+}
+
+// decodeReply resolves the Codec named in reply's Content-Type header
+// (falling back to t's default, same as Handle does on the way in) and
+// decodes its NATSOk/NATSError body into resp.
+func (t *Transport) decodeReply(reply *nats.Msg, resp any) error {
+	codec := codecFor(reply, t.codec)
+
+	var ok NATSOk
+	if err := codec.Unmarshal(reply.Data, &ok); err == nil && ok.Status >= 200 && ok.Status < 300 {
+		return decodeBody(codec, ok.Body, resp)
+	}
+
+	var natsErr NATSError
+	if err := codec.Unmarshal(reply.Data, &natsErr); err == nil && natsErr.Error != "" {
+		return fmt.Errorf("nats: status %d: %s", natsErr.Status, natsErr.Error)
+	}
+	return fmt.Errorf("nats: unrecognized response")
+}
+
+// decodeBody decodes a NATSOk.Body into resp. ProtobufCodec's
+// NATSOk.UnmarshalBinary already leaves Body as the raw encoded []byte
+// (see models.go), so that's decoded directly; every other codec leaves
+// Body as whatever generic value it decoded the reply into (e.g. a
+// map[string]any for JSON/msgpack), so it's re-encoded and decoded into
+// resp's concrete type.
+func decodeBody(codec Codec, body any, resp any) error {
+	if b, ok := body.([]byte); ok {
+		return codec.Unmarshal(b, resp)
+	}
+	b, err := codec.Marshal(body)
+	if err != nil {
+		return err
+	}
+	return codec.Unmarshal(b, resp)
+}
+
+// doRequest sends req via send, then decodes the reply through t's codec
+// machinery into a Resp. It's the shared plumbing behind NewClient and
+// Request so both honor the same Content-Type/Accept negotiation Handle
+// does on the server side.
+func doRequest[Req, Resp any](t *Transport, req Req, send func(*nats.Msg) (*nats.Msg, error)) (Resp, error) {
+	var resp Resp
+
+	msg, err := t.encodeRequest(req)
+	if err != nil {
+		return resp, err
+	}
+
+	reply, err := send(msg)
+	if err != nil {
+		return resp, err
+	}
+
+	return resp, t.decodeReply(reply, &resp)
+}
+
+// NewClient builds a typed request function for subject: it marshals req
+// with t's codec, performs a core NATS request/reply, and decodes the
+// NATSOk body into Resp using whichever codec the reply names in its
+// Content-Type header. A NATSError reply is surfaced as a Go error carrying
+// its status. This is the client-side complement to MapperHandler, so
+// callers don't have to hand-roll marshal/unmarshal around Transport.Handle
+// subjects.
+func NewClient[Req, Resp any](t *Transport, subject string) func(ctx context.Context, req Req) (Resp, error) {
+	return func(ctx context.Context, req Req) (Resp, error) {
+		return doRequest[Req, Resp](t, req, func(msg *nats.Msg) (*nats.Msg, error) {
+			msg.Subject = subject
+			return t.nc.RequestMsgWithContext(ctx, msg)
+		})
+	}
+}
+
+// Request marshals req with t's codec, sends it as a core NATS
+// request/reply, and decodes the NATSOk body into Resp using whichever
+// codec the reply names in its Content-Type header. A NATSError reply is
+// surfaced as a Go error carrying its status.
+func Request[Req, Resp any](t *Transport, subject string, req Req, timeout time.Duration) (Resp, error) {
+	return doRequest[Req, Resp](t, req, func(msg *nats.Msg) (*nats.Msg, error) {
+		msg.Subject = subject
+		return t.nc.RequestMsg(msg, timeout)
+	})
+}
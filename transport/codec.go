@@ -0,0 +1,112 @@
+package transport
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/nats-io/nats.go"
+	"github.com/vmihailenco/msgpack/v5"
+	"google.golang.org/protobuf/proto"
+)
+
+// ContentTypeHeader and AcceptHeader are the NATS message headers codecFor
+// inspects to pick a Codec for a given message: ContentTypeHeader on a
+// request names the codec its body is already encoded with, while
+// AcceptHeader (checked first, since it's the caller stating a preference)
+// names the codec the reply should use.
+const (
+	ContentTypeHeader = "Content-Type"
+	AcceptHeader      = "Accept"
+)
+
+// Codec encodes and decodes the values Handle and MapperHandler pass across
+// a NATS message boundary. ContentType identifies the wire format in the
+// ContentTypeHeader/AcceptHeader.
+type Codec interface {
+	Marshal(v any) ([]byte, error)
+	Unmarshal(data []byte, v any) error
+	ContentType() string
+}
+
+var codecs sync.Map // string -> Codec
+
+// RegisterCodec makes c selectable by its ContentType() via the
+// Content-Type/Accept headers. JSONCodec, MsgpackCodec, and ProtobufCodec
+// are registered by init under "application/json", "application/msgpack",
+// and "application/protobuf" respectively.
+func RegisterCodec(c Codec) {
+	codecs.Store(c.ContentType(), c)
+}
+
+func init() {
+	RegisterCodec(JSONCodec{})
+	RegisterCodec(MsgpackCodec{})
+	RegisterCodec(ProtobufCodec{})
+}
+
+// codecFor resolves a Codec from msg's headers: the Accept header wins if
+// present and registered, then Content-Type, falling back to def (a
+// Transport's default codec) when neither header names a registered one.
+// Handle uses it to pick the codec a reply is encoded with.
+func codecFor(msg *nats.Msg, def Codec) Codec {
+	if msg.Header != nil {
+		if name := msg.Header.Get(AcceptHeader); name != "" {
+			if c, ok := codecs.Load(name); ok {
+				return c.(Codec)
+			}
+		}
+		if name := msg.Header.Get(ContentTypeHeader); name != "" {
+			if c, ok := codecs.Load(name); ok {
+				return c.(Codec)
+			}
+		}
+	}
+	return def
+}
+
+// JSONCodec is the default Codec, backed by encoding/json.
+type JSONCodec struct{}
+
+func (JSONCodec) Marshal(v any) ([]byte, error)      { return json.Marshal(v) }
+func (JSONCodec) Unmarshal(data []byte, v any) error { return json.Unmarshal(data, v) }
+func (JSONCodec) ContentType() string                { return "application/json" }
+
+// MsgpackCodec encodes with MessagePack, a more compact binary alternative
+// to JSON with no change to the Go types involved.
+type MsgpackCodec struct{}
+
+func (MsgpackCodec) Marshal(v any) ([]byte, error)      { return msgpack.Marshal(v) }
+func (MsgpackCodec) Unmarshal(data []byte, v any) error { return msgpack.Unmarshal(data, v) }
+func (MsgpackCodec) ContentType() string                { return "application/msgpack" }
+
+// ProtobufCodec encodes with protobuf wire format. *NATSError and *NATSOk
+// implement it directly via their MarshalBinary/UnmarshalBinary methods
+// (see models.go); any other proto.Message is marshaled with the standard
+// proto library, and anything else is rejected, since there's no generic
+// protobuf mapping for an arbitrary Go type.
+type ProtobufCodec struct{}
+
+func (ProtobufCodec) Marshal(v any) ([]byte, error) {
+	switch m := v.(type) {
+	case interface{ MarshalBinary() ([]byte, error) }:
+		return m.MarshalBinary()
+	case proto.Message:
+		return proto.Marshal(m)
+	default:
+		return nil, fmt.Errorf("protobuf codec: %T does not implement MarshalBinary or proto.Message", v)
+	}
+}
+
+func (ProtobufCodec) Unmarshal(data []byte, v any) error {
+	switch m := v.(type) {
+	case interface{ UnmarshalBinary([]byte) error }:
+		return m.UnmarshalBinary(data)
+	case proto.Message:
+		return proto.Unmarshal(data, m)
+	default:
+		return fmt.Errorf("protobuf codec: %T does not implement UnmarshalBinary or proto.Message", v)
+	}
+}
+
+func (ProtobufCodec) ContentType() string { return "application/protobuf" }
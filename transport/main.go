@@ -1,11 +1,9 @@
 package transport
 
 import (
-	"encoding/json"
 	"fmt"
 	"log"
 	"sync"
-	"time"
 
 	"github.com/go-playground/validator"
 	"github.com/nats-io/nats.go"
@@ -15,17 +13,28 @@ type Transport struct {
 	nc      *nats.Conn
 	errPool *sync.Pool
 	okPool  *sync.Pool
+	codec   Codec
 }
 
 var validate = validator.New()
 
+// TransportOption configures a Transport constructed via New.
+type TransportOption func(*Transport)
+
+// WithCodec sets the Transport's default Codec, used whenever a message
+// doesn't carry a Content-Type/Accept header naming a registered one.
+// JSONCodec is the default.
+func WithCodec(c Codec) TransportOption {
+	return func(t *Transport) { t.codec = c }
+}
+
 // New initializes a new Transport instance. It connects to a NATS server using provided URL and nkey file,
 // and creates error and OK response pools for efficient handling of responses.
 // natsUrl: NATS server URL
 // nkeyFile: path to the nkey file for authentication
 // name: name of the NATS client
 // Returns a pointer to a Transport instance or an error.
-func New(natsUrl, nkeyFile, name string) (*Transport, error) {
+func New(natsUrl, nkeyFile, name string, opts ...TransportOption) (*Transport, error) {
 	var errPool = &sync.Pool{
 		New: func() interface{} {
 			return &NATSError{}
@@ -47,36 +56,43 @@ func New(natsUrl, nkeyFile, name string) (*Transport, error) {
 		return nil, err
 	}
 
-	return &Transport{
+	t := &Transport{
 		nc:      nc,
 		errPool: errPool,
 		okPool:  okPool,
-	}, nil
+		codec:   JSONCodec{},
+	}
+	for _, o := range opts {
+		o(t)
+	}
+	return t, nil
 }
 
-// Handle subscribes to a topic in the message broker, executes a function 'fn' for each received message,
-// and sends a response back. It logs the processing time for each message and handles any errors that occur,
+// Handle subscribes to a topic in the message broker, executes a function 'fn' for each received message
+// through the given middlewares, and sends a response back. Middlewares run in the order given, the first
+// one listed being outermost, and handle any errors that occur,
 // using pools for error and OK responses to optimize resource usage.
 // e: topic to subscribe to
 // fn: function to process each message received
-func (t *Transport) Handle(e string, fn func(*nats.Msg) (any, int, error)) {
+// mws: middlewares to wrap fn with, outermost first
+func (t *Transport) Handle(e string, fn HandlerFunc, mws ...Middleware) {
+	handler := chain(fn, mws...)
+
 	log.Printf("Subsribing to %s...\n", e)
 	t.nc.Subscribe(e, func(msg *nats.Msg) {
-		defer func(t time.Time) {
-			log.Printf("%s spend %v", e, time.Since(t))
-		}(time.Now())
+		codec := codecFor(msg, t.codec)
 
-		v, c, err := fn(msg)
+		v, c, err := handler(msg)
 		if err != nil {
 			errMsg := t.getErrorFromPool(c, err.Error())
 			defer t.returnErrorToPool(errMsg)
 
-			resp, err := json.Marshal(errMsg)
+			resp, err := codec.Marshal(errMsg)
 			if err != nil {
 				log.Println(err)
 				return
 			}
-			if err := msg.Respond(resp); err != nil {
+			if err := t.respond(msg, codec, resp); err != nil {
 				log.Println(err)
 			}
 			return
@@ -85,18 +101,28 @@ func (t *Transport) Handle(e string, fn func(*nats.Msg) (any, int, error)) {
 		o := t.getOkFromPool(c, v)
 		defer t.returnOkToPool(o)
 
-		resp, err := json.Marshal(o)
+		resp, err := codec.Marshal(o)
 		if err != nil {
 			log.Println(err)
 			return
 		}
-		if err := msg.Respond(resp); err != nil {
+		if err := t.respond(msg, codec, resp); err != nil {
 			log.Println(err)
 		}
 	})
 	log.Printf("Subsribed to %s\n", e)
 }
 
+// respond replies to msg with data, stamping a Content-Type header so the
+// caller knows which codec to decode the reply with.
+func (t *Transport) respond(msg *nats.Msg, codec Codec, data []byte) error {
+	return msg.RespondMsg(&nats.Msg{
+		Subject: msg.Reply,
+		Data:    data,
+		Header:  nats.Header{ContentTypeHeader: []string{codec.ContentType()}},
+	})
+}
+
 // MapperHandler creates a function that serves as a bridge between your database
 // and a message broker (nats.Msg). It takes a function as a parameter that retrieves
 // a value of type V from a pointer to a value of type R, and maps this to a nats.Msg.
@@ -120,11 +146,23 @@ func (t *Transport) Handle(e string, fn func(*nats.Msg) (any, int, error)) {
 // Returns: A function that takes a pointer to nats.Msg and returns a value of type any,
 // a status code of type int, and an error. This returned function serves as the handler
 // for processing the message broker data and mapping it to the database model.
-func MapperHandler[R, V any](dbFn func(*R) (V, error)) func(*nats.Msg) (any, int, error) {
+//
+// The request body is decoded with the Codec the message's Content-Type
+// header names, falling back to t's default codec (Accept only picks the
+// reply's codec, in Handle); R
+// itself is effectively the "registered type" for a protobuf subject, since
+// passing a proto.Message as R makes ProtobufCodec decode it directly.
+func MapperHandler[R, V any](t *Transport, dbFn func(*R) (V, error)) HandlerFunc {
 	return func(m *nats.Msg) (any, int, error) {
 		var r R
 		if m.Data != nil && len(m.Data) > 0 {
-			if err := json.Unmarshal(m.Data, &r); err != nil {
+			dec := t.codec
+			if m.Header != nil {
+				if c, ok := codecs.Load(m.Header.Get(ContentTypeHeader)); ok {
+					dec = c.(Codec)
+				}
+			}
+			if err := dec.Unmarshal(m.Data, &r); err != nil {
 				return nil, 400, err
 			}
 			if err := validate.Struct(r); err != nil {
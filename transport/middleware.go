@@ -0,0 +1,197 @@
+package transport
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/nats-io/nats.go"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// HandlerFunc is the shape of a message handler passed to Handle, and the
+// unit Middleware wraps.
+type HandlerFunc func(*nats.Msg) (any, int, error)
+
+// Middleware wraps a HandlerFunc to run logic before and/or after it.
+// Middlewares are applied in the order given to Handle, so the first one
+// listed is the outermost.
+type Middleware func(next HandlerFunc) HandlerFunc
+
+// chain composes middlewares around fn, outermost first.
+func chain(fn HandlerFunc, mws ...Middleware) HandlerFunc {
+	for i := len(mws) - 1; i >= 0; i-- {
+		fn = mws[i](fn)
+	}
+	return fn
+}
+
+// RequestIDHeader is the NATS message header carrying the request id that
+// WithRequestID propagates.
+const RequestIDHeader = "Request-Id"
+
+// WithRequestID copies an inbound Request-Id header onto the context-free
+// reply path by stamping it back on msg.Header, generating a fresh unique id
+// with nats.NewInbox when the caller didn't send one,
+// so every log line and downstream call can be correlated.
+func WithRequestID() Middleware {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(msg *nats.Msg) (any, int, error) {
+			if msg.Header == nil {
+				msg.Header = nats.Header{}
+			}
+			if msg.Header.Get(RequestIDHeader) == "" {
+				msg.Header.Set(RequestIDHeader, nats.NewInbox())
+			}
+			return next(msg)
+		}
+	}
+}
+
+// WithLogging replaces the ad-hoc log.Printf timing in Handle with a
+// structured line per message, including the request id when present.
+func WithLogging() Middleware {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(msg *nats.Msg) (any, int, error) {
+			start := time.Now()
+			v, status, err := next(msg)
+			log.Printf("subject=%s request_id=%s status=%d duration=%s err=%v",
+				msg.Subject, requestID(msg), status, time.Since(start), err)
+			return v, status, err
+		}
+	}
+}
+
+func requestID(msg *nats.Msg) string {
+	if msg.Header == nil {
+		return ""
+	}
+	return msg.Header.Get(RequestIDHeader)
+}
+
+// tracer is the package-wide OpenTelemetry tracer used by WithTracing.
+var tracer = otel.Tracer("github.com/AltairInglorious/nexus/transport")
+
+// WithTracing opens a span per subject for the duration of the handler,
+// replacing the bare duration that used to only reach the log.
+func WithTracing() Middleware {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(msg *nats.Msg) (any, int, error) {
+			ctx, span := tracer.Start(context.Background(), msg.Subject,
+				trace.WithAttributes(attribute.String("request_id", requestID(msg))))
+			defer span.End()
+			_ = ctx
+
+			v, status, err := next(msg)
+			span.SetAttributes(attribute.Int("status", status))
+			if err != nil {
+				span.RecordError(err)
+				span.SetStatus(codes.Error, err.Error())
+			}
+			return v, status, err
+		}
+	}
+}
+
+// WithRecover turns a panic inside the wrapped handler into a 500 error
+// instead of crashing the NATS message dispatch goroutine.
+func WithRecover() Middleware {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(msg *nats.Msg) (v any, status int, err error) {
+			defer func() {
+				if r := recover(); r != nil {
+					log.Printf("subject=%s panic: %v", msg.Subject, r)
+					v, status, err = nil, 500, fmt.Errorf("panic: %v", r)
+				}
+			}()
+			return next(msg)
+		}
+	}
+}
+
+// tokenBucket is a minimal per-key token bucket used by WithRateLimit.
+type tokenBucket struct {
+	rate     float64
+	burst    float64
+	tokens   float64
+	lastTake time.Time
+}
+
+func (b *tokenBucket) allow(now time.Time) bool {
+	elapsed := now.Sub(b.lastTake).Seconds()
+	b.lastTake = now
+	b.tokens += elapsed * b.rate
+	if b.tokens > b.burst {
+		b.tokens = b.burst
+	}
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// WithRateLimit limits each subject to ratePerSecond messages per second,
+// with a burst allowance of burst. Requests past the limit get a 429.
+func WithRateLimit(ratePerSecond float64, burst int) Middleware {
+	var mu sync.Mutex
+	buckets := map[string]*tokenBucket{}
+
+	return func(next HandlerFunc) HandlerFunc {
+		return func(msg *nats.Msg) (any, int, error) {
+			mu.Lock()
+			b, ok := buckets[msg.Subject]
+			if !ok {
+				b = &tokenBucket{rate: ratePerSecond, burst: float64(burst), tokens: float64(burst), lastTake: time.Now()}
+				buckets[msg.Subject] = b
+			}
+			allowed := b.allow(time.Now())
+			mu.Unlock()
+
+			if !allowed {
+				return nil, 429, errors.New("rate limit exceeded")
+			}
+			return next(msg)
+		}
+	}
+}
+
+// AuthHeader is the NATS message header WithAuth reads the bearer JWT from.
+const AuthHeader = "Authorization"
+
+// bearerPrefix is the conventional "Bearer <token>" scheme prefix WithAuth
+// strips before handing the token to jwt.Parse.
+const bearerPrefix = "Bearer "
+
+// WithAuth verifies a JWT bearer token from the AuthHeader on every message
+// using keyFunc (see jwt.Parse), rejecting the message with a 401 when it's
+// missing or invalid. The header value may carry the conventional "Bearer "
+// scheme prefix or just the bare token; either way the token itself is what
+// gets parsed.
+func WithAuth(keyFunc jwt.Keyfunc) Middleware {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(msg *nats.Msg) (any, int, error) {
+			if msg.Header == nil {
+				return nil, 401, errors.New("missing authorization header")
+			}
+			raw := msg.Header.Get(AuthHeader)
+			if raw == "" {
+				return nil, 401, errors.New("missing authorization header")
+			}
+			raw = strings.TrimPrefix(raw, bearerPrefix)
+
+			if _, err := jwt.Parse(raw, keyFunc); err != nil {
+				return nil, 401, fmt.Errorf("invalid token: %w", err)
+			}
+			return next(msg)
+		}
+	}
+}
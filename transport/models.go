@@ -1,5 +1,13 @@
 package transport
 
+import (
+	"encoding/json"
+	"fmt"
+
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+)
+
 type NATSError struct {
 	Status int    `json:"status,omitempty"`
 	Error  string `json:"error,omitempty"`
@@ -9,3 +17,145 @@ type NATSOk struct {
 	Status int `json:"status,omitempty"`
 	Body   any `json:"body,omitempty"`
 }
+
+// MarshalBinary gives NATSError a compact protobuf-wire-format encoding
+// (field 1 = status varint, field 2 = error string), hand-encoded since
+// NATSError isn't a .proto-generated type.
+func (e *NATSError) MarshalBinary() ([]byte, error) {
+	var b []byte
+	b = protowire.AppendTag(b, 1, protowire.VarintType)
+	b = protowire.AppendVarint(b, uint64(e.Status))
+	b = protowire.AppendTag(b, 2, protowire.BytesType)
+	b = protowire.AppendString(b, e.Error)
+	return b, nil
+}
+
+// UnmarshalBinary decodes the form written by MarshalBinary, resetting e
+// first so pooled instances don't retain a previous message's fields.
+func (e *NATSError) UnmarshalBinary(data []byte) error {
+	e.Status = 0
+	e.Error = ""
+	for len(data) > 0 {
+		num, typ, n := protowire.ConsumeTag(data)
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		data = data[n:]
+
+		switch num {
+		case 1:
+			v, n := protowire.ConsumeVarint(data)
+			if n < 0 {
+				return protowire.ParseError(n)
+			}
+			e.Status = int(v)
+			data = data[n:]
+		case 2:
+			v, n := protowire.ConsumeString(data)
+			if n < 0 {
+				return protowire.ParseError(n)
+			}
+			e.Error = v
+			data = data[n:]
+		default:
+			n := protowire.ConsumeFieldValue(num, typ, data)
+			if n < 0 {
+				return protowire.ParseError(n)
+			}
+			data = data[n:]
+		}
+	}
+	return nil
+}
+
+// MarshalBinary gives NATSOk a compact protobuf-wire-format encoding (field
+// 1 = status varint, field 2 = body bytes). Body is encoded via marshalBody:
+// a proto.Message is marshaled directly, a []byte is passed through
+// untouched (so large payloads skip JSON overhead entirely), and anything
+// else falls back to JSON for that one field.
+func (o *NATSOk) MarshalBinary() ([]byte, error) {
+	body, err := marshalBody(o.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	var b []byte
+	b = protowire.AppendTag(b, 1, protowire.VarintType)
+	b = protowire.AppendVarint(b, uint64(o.Status))
+	b = protowire.AppendTag(b, 2, protowire.BytesType)
+	b = protowire.AppendBytes(b, body)
+	return b, nil
+}
+
+// UnmarshalBinary decodes the form written by MarshalBinary. Body is always
+// populated as the raw []byte written by marshalBody, since NATSOk has no
+// way to know its original proto.Message type on the decode side; callers
+// that need a typed Body should decode it themselves (e.g. via a
+// proto.Message they unmarshal the bytes into).
+func (o *NATSOk) UnmarshalBinary(data []byte) error {
+	o.Status = 0
+	o.Body = nil
+	for len(data) > 0 {
+		num, typ, n := protowire.ConsumeTag(data)
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		data = data[n:]
+
+		switch num {
+		case 1:
+			v, n := protowire.ConsumeVarint(data)
+			if n < 0 {
+				return protowire.ParseError(n)
+			}
+			o.Status = int(v)
+			data = data[n:]
+		case 2:
+			v, n := protowire.ConsumeBytes(data)
+			if n < 0 {
+				return protowire.ParseError(n)
+			}
+			o.Body = append([]byte(nil), v...)
+			data = data[n:]
+		default:
+			n := protowire.ConsumeFieldValue(num, typ, data)
+			if n < 0 {
+				return protowire.ParseError(n)
+			}
+			data = data[n:]
+		}
+	}
+	return nil
+}
+
+// marshalBody encodes an NATSOk.Body for protobuf transport: a proto.Message
+// is marshaled with the standard library, a []byte passes through
+// untouched, and anything else is JSON-encoded, since it has no protobuf
+// mapping of its own.
+func marshalBody(body any) ([]byte, error) {
+	switch v := body.(type) {
+	case nil:
+		return nil, nil
+	case []byte:
+		return v, nil
+	case proto.Message:
+		return proto.Marshal(v)
+	default:
+		b, err := json.Marshal(v)
+		if err != nil {
+			return nil, fmt.Errorf("protobuf: marshal body: %w", err)
+		}
+		return b, nil
+	}
+}
+
+// DeadLetterEnvelope is published to a StreamOptions.DeadLetter subject
+// when HandleStream terminates a message that failed permanently (a 4xx
+// status). A message that keeps failing with any other status is only
+// nak'd for redelivery and never dead-lettered.
+type DeadLetterEnvelope struct {
+	Subject string `json:"subject"`
+	Status  int    `json:"status,omitempty"`
+	Error   string `json:"error,omitempty"`
+	Data    []byte `json:"data,omitempty"`
+}
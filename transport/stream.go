@@ -0,0 +1,181 @@
+package transport
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/nats-io/nats.go"
+)
+
+// StreamOptions configures HandleStream's JetStream durable pull consumer.
+type StreamOptions struct {
+	// Stream is created (or left as-is if it already exists) before the
+	// consumer is set up. Required.
+	Stream *nats.StreamConfig
+	// Consumer is created (or left as-is if it already exists) on Stream.
+	// Consumer.Durable must be set. Required.
+	Consumer *nats.ConsumerConfig
+
+	// Concurrency is how many goroutines pull and invoke fn concurrently.
+	// Defaults to 1.
+	Concurrency int
+	// BatchSize is how many messages each pull fetches at once. Defaults to 10.
+	BatchSize int
+	// PullTimeout bounds how long a pull waits for messages. Defaults to 5s.
+	PullTimeout time.Duration
+	// RetryBackoff is the Nak delay used for a transient failure, indexed
+	// by delivery attempt (1st retry uses RetryBackoff[0], and so on; the
+	// last entry is reused for further attempts). Empty means redeliver
+	// immediately.
+	RetryBackoff []time.Duration
+	// DeadLetter, if set, receives a DeadLetterEnvelope for every message
+	// HandleStream terminates permanently.
+	DeadLetter string
+}
+
+// HandleStream subscribes to subject as a JetStream durable pull consumer,
+// creating Stream/Consumer if they don't already exist, and invokes fn for
+// each message with configurable concurrency. fn's returned status decides
+// the message's fate: a 2xx acks it, a 4xx terminates it as permanently
+// failed (publishing a copy to DeadLetter first, if set), and anything else
+// (5xx, or a non-nil err with no clearer status) naks it for redelivery
+// with RetryBackoff applied.
+func (t *Transport) HandleStream(subject string, opts StreamOptions, fn func(*nats.Msg) (any, int, error)) error {
+	js, err := t.nc.JetStream()
+	if err != nil {
+		return err
+	}
+	if opts.Stream == nil || opts.Consumer == nil || opts.Consumer.Durable == "" {
+		return errors.New("transport: HandleStream requires a Stream and a durable Consumer")
+	}
+
+	if _, err := js.AddStream(opts.Stream); err != nil && !errors.Is(err, nats.ErrStreamNameAlreadyInUse) {
+		return fmt.Errorf("transport: add stream %s: %w", opts.Stream.Name, err)
+	}
+	if _, err := js.AddConsumer(opts.Stream.Name, opts.Consumer); err != nil && !errors.Is(err, nats.ErrConsumerNameAlreadyInUse) {
+		return fmt.Errorf("transport: add consumer %s: %w", opts.Consumer.Durable, err)
+	}
+
+	sub, err := js.PullSubscribe(subject, opts.Consumer.Durable)
+	if err != nil {
+		return err
+	}
+
+	concurrency := opts.Concurrency
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+	batch := opts.BatchSize
+	if batch <= 0 {
+		batch = 10
+	}
+	pullTimeout := opts.PullTimeout
+	if pullTimeout <= 0 {
+		pullTimeout = 5 * time.Second
+	}
+
+	log.Printf("Pulling from %s with %d worker(s)...\n", subject, concurrency)
+	for i := 0; i < concurrency; i++ {
+		go t.pullLoop(sub, batch, pullTimeout, opts, fn)
+	}
+	return nil
+}
+
+func (t *Transport) pullLoop(sub *nats.Subscription, batch int, timeout time.Duration, opts StreamOptions, fn func(*nats.Msg) (any, int, error)) {
+	for {
+		msgs, err := sub.Fetch(batch, nats.MaxWait(timeout))
+		if err != nil {
+			if errors.Is(err, nats.ErrTimeout) {
+				continue
+			}
+			log.Println(err)
+			continue
+		}
+		for _, msg := range msgs {
+			t.handleStreamMsg(msg, opts, fn)
+		}
+	}
+}
+
+func (t *Transport) handleStreamMsg(msg *nats.Msg, opts StreamOptions, fn func(*nats.Msg) (any, int, error)) {
+	defer func(start time.Time) {
+		log.Printf("%s spend %v", msg.Subject, time.Since(start))
+	}(time.Now())
+
+	_, status, err := fn(msg)
+	switch {
+	case err == nil && status >= 200 && status < 300:
+		if ackErr := msg.Ack(); ackErr != nil {
+			log.Println(ackErr)
+		}
+	case status >= 400 && status < 500:
+		t.publishDeadLetter(msg, opts.DeadLetter, status, err)
+		if termErr := msg.Term(); termErr != nil {
+			log.Println(termErr)
+		}
+	default:
+		delay := retryDelay(opts.RetryBackoff, deliveryAttempt(msg))
+		if nakErr := msg.NakWithDelay(delay); nakErr != nil {
+			log.Println(nakErr)
+		}
+	}
+}
+
+func (t *Transport) publishDeadLetter(msg *nats.Msg, subject string, status int, err error) {
+	if subject == "" {
+		return
+	}
+
+	env := DeadLetterEnvelope{Subject: msg.Subject, Status: status, Data: msg.Data}
+	if err != nil {
+		env.Error = err.Error()
+	}
+
+	b, merr := json.Marshal(env)
+	if merr != nil {
+		log.Println(merr)
+		return
+	}
+	if perr := t.nc.Publish(subject, b); perr != nil {
+		log.Println(perr)
+	}
+}
+
+func deliveryAttempt(msg *nats.Msg) int {
+	meta, err := msg.Metadata()
+	if err != nil || meta == nil {
+		return 1
+	}
+	return int(meta.NumDelivered)
+}
+
+func retryDelay(backoff []time.Duration, attempt int) time.Duration {
+	if len(backoff) == 0 {
+		return 0
+	}
+	idx := attempt - 1
+	if idx < 0 {
+		idx = 0
+	}
+	if idx >= len(backoff) {
+		idx = len(backoff) - 1
+	}
+	return backoff[idx]
+}
+
+// Publish marshals v as JSON and publishes it to subject through
+// JetStream, returning the resulting PubAck once the server has stored it.
+func Publish[T any](t *Transport, subject string, v T) (*nats.PubAck, error) {
+	js, err := t.nc.JetStream()
+	if err != nil {
+		return nil, err
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return js.Publish(subject, b)
+}